@@ -1,34 +1,57 @@
 package backup
 
 import (
+	"context"
+	"fmt"
 	"github.com/timabell/disk-hog-backup/backup_sets"
+	"github.com/timabell/disk-hog-backup/dhbfs"
 	"github.com/timabell/disk-hog-backup/dhcopy"
-	"github.com/timabell/disk-hog-backup/hard_linker"
+	"github.com/timabell/disk-hog-backup/filter"
+	"github.com/timabell/disk-hog-backup/manifest"
 	"log"
 	"os"
 	"path/filepath"
 	"time"
 )
 
-func Backup(source string, dest string, getTime func () (time.Time)) (setName string, err error) {
-	err = os.MkdirAll(dest, os.ModePerm)
-	if err != nil {
-		log.Fatal(err)
+// Backup creates a new backup set of source under dest. Each file is
+// compared against the previous set's manifest; files that are unchanged
+// are hard-linked rather than re-copied, and everything else is copied
+// fresh. It returns ctx.Err() promptly if ctx is cancelled mid-run,
+// leaving no half-written set behind beyond what had already completed.
+func Backup(ctx context.Context, fs dhbfs.FS, source string, dest string, getTime func() time.Time, filterOpt *filter.FilterOpt) (setName string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
-	lastSetName, err := backup_sets.FindLatestSet(dest)
+	err = dhbfs.MkdirAll(fs, dest, os.ModePerm)
 	if err != nil {
-		log.Fatalf("Failed to search for previous backup set: %s", err)
+		return "", err
 	}
-	setName, err = backup_sets.CreateEmptySet(dest, getTime)
+	lastSetName, err := backup_sets.FindLatestSet(ctx, fs, dest)
 	if err != nil {
-		log.Fatalf("Couldn't create set folder: %s", err)
+		return "", fmt.Errorf("failed to search for previous backup set: %w", err)
 	}
-	destFolder := filepath.Join(dest, setName)
+	var lastSetPath string
+	var prevManifest manifest.Manifest
 	if lastSetName != "" {
-		lastSetPath := filepath.Join(dest, lastSetName)
-		hard_linker.HardLinkCopy(lastSetPath, destFolder)
+		lastSetPath = filepath.Join(dest, lastSetName)
+		prevManifest, err = manifest.Load(fs, manifest.Path(dest, lastSetName))
+		if err != nil {
+			return "", fmt.Errorf("failed to load manifest for %v: %w", lastSetName, err)
+		}
+	}
+	setName, err = backup_sets.CreateEmptySet(ctx, fs, dest, getTime)
+	if err != nil {
+		return "", fmt.Errorf("couldn't create set folder: %w", err)
 	}
+	destFolder := filepath.Join(dest, setName)
 	log.Printf("backing up %v into %v\n", source, destFolder)
-	err = dhcopy.CopyFolder(source, destFolder)
-	return
+	newManifest, err := dhcopy.CopyFolderIncremental(ctx, fs, source, destFolder, lastSetPath, prevManifest, filterOpt)
+	if err != nil {
+		return setName, err
+	}
+	if err := manifest.Save(fs, manifest.Path(dest, setName), newManifest); err != nil {
+		return setName, fmt.Errorf("failed to save manifest for %v: %w", setName, err)
+	}
+	return setName, nil
 }
@@ -1,7 +1,9 @@
 package backup
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
 	"github.com/timabell/disk-hog-backup/test_helpers"
 	"io/ioutil"
 	"os"
@@ -20,7 +22,7 @@ func TestBackup(t *testing.T) {
 	defer os.RemoveAll(dest) // comment this out to be able to inspect what we actually got
 
 	//smoke test
-	setName, err := Backup(source, dest, time.Now)
+	setName, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest, time.Now, nil)
 	assert.NoError(t, err)
 
 	// Just a quick check that deeply nested file is copied.
@@ -33,6 +35,19 @@ func TestBackupNonExistentPath(t *testing.T) {
 	t.Skip("todo")
 }
 
+func TestBackupHonoursCancelledContext(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Backup(ctx, dhbfs.NewOsFS(), source, dest, time.Now, nil)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func TestCreatesDestinationFolder(t *testing.T) {
 	source := createSource()
 	defer os.RemoveAll(source)
@@ -41,13 +56,13 @@ func TestCreatesDestinationFolder(t *testing.T) {
 
 	nonExistentDestination := filepath.Join(dest, "to-be-created")
 
-	Backup(source, nonExistentDestination, time.Now)
+	Backup(context.Background(), dhbfs.NewOsFS(), source, nonExistentDestination, time.Now, nil)
 
 	_, err := ioutil.ReadDir(nonExistentDestination)
 	assert.NoError(t, err, "destination folder should be copied")
 }
 
-func TestHardLinksSecondBackup(t *testing.T){
+func TestHardLinksSecondBackup(t *testing.T) {
 	source := createSource()
 	defer os.RemoveAll(source)
 	const filename = "linkme.txt"
@@ -58,13 +73,13 @@ func TestHardLinksSecondBackup(t *testing.T){
 	baseDate := time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC)
 
 	// first backup
-	setName1, err := Backup(source, dest,
-		test_helpers.FixedTime(baseDate.Add(time.Hour)))
+	setName1, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest,
+		test_helpers.FixedTime(baseDate.Add(time.Hour)), nil)
 	assert.NoError(t, err)
 
 	// second backup
-	setName2, err := Backup(source, dest,
-		test_helpers.FixedTime(baseDate.Add(time.Hour*2)))
+	setName2, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest,
+		test_helpers.FixedTime(baseDate.Add(time.Hour*2)), nil)
 	assert.NoError(t, err)
 
 	sourceFile, err := os.Stat(filepath.Join(dest, setName1, filename))
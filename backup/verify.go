@@ -0,0 +1,81 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/manifest"
+)
+
+// VerifyStatus is the outcome of checking a single manifest entry.
+type VerifyStatus int
+
+const (
+	OK VerifyStatus = iota
+	Missing
+	Corrupt
+)
+
+func (s VerifyStatus) String() string {
+	switch s {
+	case OK:
+		return "ok"
+	case Missing:
+		return "missing"
+	case Corrupt:
+		return "corrupt"
+	default:
+		return "unknown"
+	}
+}
+
+// VerifyResult reports what verification found for one file in a set.
+type VerifyResult struct {
+	Path   string
+	Status VerifyStatus
+}
+
+// Verify re-hashes every file recorded in setName's manifest and reports
+// any that are missing, or whose content no longer matches the digest
+// recorded when the set was created, i.e. bitrot.
+func Verify(ctx context.Context, fs dhbfs.FS, dest string, setName string) (results []VerifyResult, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	m, err := manifest.Load(fs, manifest.Path(dest, setName))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load manifest for %v: %w", setName, err)
+	}
+
+	relPaths := make([]string, 0, len(m))
+	for relPath := range m {
+		relPaths = append(relPaths, relPath)
+	}
+	sort.Strings(relPaths)
+
+	setFolder := filepath.Join(dest, setName)
+	for _, relPath := range relPaths {
+		if err := ctx.Err(); err != nil {
+			return results, err
+		}
+		filePath := filepath.Join(setFolder, relPath)
+		sum, err := manifest.Hash(ctx, fs, filePath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				results = append(results, VerifyResult{Path: relPath, Status: Missing})
+				continue
+			}
+			return results, err
+		}
+		status := OK
+		if sum != m[relPath].Sha256 {
+			status = Corrupt
+		}
+		results = append(results, VerifyResult{Path: relPath, Status: status})
+	}
+	return results, nil
+}
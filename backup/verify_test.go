@@ -0,0 +1,77 @@
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/test_helpers"
+)
+
+func TestVerifyReportsOkForUnchangedSet(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	setName, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest, time.Now, nil)
+	assert.NoError(t, err)
+
+	results, err := Verify(context.Background(), dhbfs.NewOsFS(), dest, setName)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, results)
+	for _, result := range results {
+		assert.Equal(t, OK, result.Status, result.Path)
+	}
+}
+
+func TestVerifyReportsCorruptFile(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	setName, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest, time.Now, nil)
+	assert.NoError(t, err)
+
+	tamperedPath := filepath.Join(deepPath, "testfile.txt")
+	f, err := os.Create(filepath.Join(dest, setName, tamperedPath))
+	assert.NoError(t, err)
+	_, err = f.WriteString("tampered")
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	results, err := Verify(context.Background(), dhbfs.NewOsFS(), dest, setName)
+	assert.NoError(t, err)
+	assert.Equal(t, Corrupt, statusOf(results, tamperedPath))
+}
+
+func TestVerifyReportsMissingFile(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	setName, err := Backup(context.Background(), dhbfs.NewOsFS(), source, dest, time.Now, nil)
+	assert.NoError(t, err)
+
+	missingPath := filepath.Join(deepPath, "testfile.txt")
+	assert.NoError(t, os.Remove(filepath.Join(dest, setName, missingPath)))
+
+	results, err := Verify(context.Background(), dhbfs.NewOsFS(), dest, setName)
+	assert.NoError(t, err)
+	assert.Equal(t, Missing, statusOf(results, missingPath))
+}
+
+func statusOf(results []VerifyResult, path string) VerifyStatus {
+	for _, result := range results {
+		if result.Path == path {
+			return result.Status
+		}
+	}
+	return -1
+}
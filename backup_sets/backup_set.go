@@ -1,25 +1,33 @@
 package backup_sets
 
 import (
-	"io/ioutil"
+	"context"
 	"os"
 	"path/filepath"
 	"time"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
 )
 
-func CreateEmptySet(dest string, getTime func() time.Time) (setName string, err error) {
+func CreateEmptySet(ctx context.Context, fs dhbfs.FS, dest string, getTime func() time.Time) (setName string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	setName = GenerateName(getTime)
 	destFolder := filepath.Join(dest, setName)
-	err = os.MkdirAll(destFolder, os.ModePerm)
+	err = dhbfs.MkdirAll(fs, destFolder, os.ModePerm)
 	return
 }
 
-func FindLatestSet(dest string) (setName string, err error) {
-	contents, err := ioutil.ReadDir(dest)
+func FindLatestSet(ctx context.Context, fs dhbfs.FS, dest string) (setName string, err error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	contents, err := fs.ReadDir(dest)
 	if err != nil {
 		return "", err
 	}
-	backupSets := filterDir(contents, func(info os.FileInfo) bool {
+	backupSets := filterDir(contents, func(info dhbfs.DirEntry) bool {
 		return IsBackupSetName(info.Name())
 	})
 	if len(backupSets) < 1 {
@@ -28,11 +36,11 @@ func FindLatestSet(dest string) (setName string, err error) {
 	return backupSets[len(backupSets)-1].Name(), nil
 }
 
-func filterDir(list []os.FileInfo, f func(info os.FileInfo) bool) (results []os.FileInfo) {
+func filterDir(list []dhbfs.DirEntry, f func(info dhbfs.DirEntry) bool) (results []dhbfs.DirEntry) {
 	for _, item := range list {
 		if f(item) {
 			results = append(results, item)
 		}
 	}
 	return results
-}
\ No newline at end of file
+}
@@ -1,7 +1,9 @@
 package backup_sets
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
 	"github.com/timabell/disk-hog-backup/test_helpers"
 	"io/ioutil"
 	"os"
@@ -20,7 +22,7 @@ func TestCreation(t *testing.T) {
 	expectedSetName := GenerateName(timeFixer) // figure out the generated set name, don't want to add DI mess to method signatures to inject it
 
 	// act
-	actualSetName, err := CreateEmptySet(dest, timeFixer)
+	actualSetName, err := CreateEmptySet(context.Background(), dhbfs.NewOsFS(), dest, timeFixer)
 	assert.NoError(t, err)
 	assert.Equal(t, expectedSetName, actualSetName)
 
@@ -37,18 +39,18 @@ func TestFindLatestSet(t *testing.T) {
 
 	// create out of order to differentiate creation order form file name order
 	baseDate := time.Date(2019, 12, 31, 23, 59, 0, 0, time.UTC)
-	_, err := CreateEmptySet(dest,
+	_, err := CreateEmptySet(context.Background(), dhbfs.NewOsFS(), dest,
 		test_helpers.FixedTime(baseDate.Add(time.Second)))
 	assert.NoError(t, err)
-	expectedSetName, err := CreateEmptySet(dest,
+	expectedSetName, err := CreateEmptySet(context.Background(), dhbfs.NewOsFS(), dest,
 		test_helpers.FixedTime(baseDate.Add(time.Second*3)))
 	assert.NoError(t, err)
-	_, err = CreateEmptySet(dest,
+	_, err = CreateEmptySet(context.Background(), dhbfs.NewOsFS(), dest,
 		test_helpers.FixedTime(baseDate.Add(time.Second*2)))
 	assert.NoError(t, err)
 
 	// act
-	actualSetName, err := FindLatestSet(dest)
+	actualSetName, err := FindLatestSet(context.Background(), dhbfs.NewOsFS(), dest)
 
 	// assert
 	assert.NoError(t, err)
@@ -61,7 +63,7 @@ func TestFindLatestSet_WhenNoSets(t *testing.T) {
 	defer os.RemoveAll(dest)
 
 	// act
-	actualSetName, err := FindLatestSet(dest)
+	actualSetName, err := FindLatestSet(context.Background(), dhbfs.NewOsFS(), dest)
 
 	// assert
 	assert.NoError(t, err)
@@ -78,7 +80,7 @@ func TestFindLatestSet_IgnoresOtherFolders(t *testing.T) {
 	assert.NoError(t, err)
 
 	// act
-	actualSetName, err := FindLatestSet(dest)
+	actualSetName, err := FindLatestSet(context.Background(), dhbfs.NewOsFS(), dest)
 
 	// assert
 	assert.NoError(t, err)
@@ -0,0 +1,274 @@
+package backup_sets
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/manifest"
+)
+
+// RetentionPolicy expresses restic/Time-Machine style pruning rules.
+// KeepLast keeps that many of the newest sets outright; each KeepHourly,
+// KeepDaily, KeepWeekly, KeepMonthly and KeepYearly keeps the newest set
+// falling into each of that many of the most recent buckets of that
+// granularity; KeepWithin keeps every set newer than now minus the
+// duration. A set survives pruning if any single rule keeps it.
+type RetentionPolicy struct {
+	KeepLast    int
+	KeepHourly  int
+	KeepDaily   int
+	KeepWeekly  int
+	KeepMonthly int
+	KeepYearly  int
+	KeepWithin  time.Duration
+}
+
+const timestampLayout = "20060102-150405"
+
+// setTime is a backup set alongside the timestamp parsed out of its
+// name.
+type setTime struct {
+	name string
+	when time.Time
+}
+
+// listSets returns every backup set under dest, newest first.
+func listSets(ctx context.Context, fs dhbfs.FS, dest string) ([]setTime, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	contents, err := fs.ReadDir(dest)
+	if err != nil {
+		return nil, err
+	}
+	var sets []setTime
+	for _, info := range contents {
+		if !IsBackupSetName(info.Name()) {
+			continue
+		}
+		when, err := parseSetTime(info.Name())
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, setTime{name: info.Name(), when: when})
+	}
+	sort.Slice(sets, func(i, j int) bool { return sets[i].when.After(sets[j].when) })
+	return sets, nil
+}
+
+// parseSetTime recovers the time.Time embedded in a set name by
+// GenerateName, the reverse of that formatting.
+func parseSetTime(name string) (time.Time, error) {
+	stamp := strings.TrimPrefix(name, prefix+"-")
+	when, err := time.ParseInLocation(timestampLayout, stamp, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("couldn't parse timestamp out of set name %v: %w", name, err)
+	}
+	return when, nil
+}
+
+// bucketKeeper groups sets by a granularity-specific key (hour, day,
+// week, ...) and keeps the newest set in each of the first quota distinct
+// buckets encountered. sets must already be newest-first.
+func bucketKeeper(sets []setTime, quota int, bucketKey func(time.Time) string) map[string]bool {
+	keep := map[string]bool{}
+	if quota <= 0 {
+		return keep
+	}
+	seen := make(map[string]bool, quota)
+	for _, s := range sets {
+		key := bucketKey(s.when)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		keep[s.name] = true
+		if len(seen) == quota {
+			break
+		}
+	}
+	return keep
+}
+
+func hourlyBucket(t time.Time) string {
+	return fmt.Sprintf("%04d-%02d-%02d-%02d", t.Year(), t.Month(), t.Day(), t.Hour())
+}
+
+func dailyBucket(t time.Time) string {
+	return fmt.Sprintf("%04d-%02d-%02d", t.Year(), t.Month(), t.Day())
+}
+
+func weeklyBucket(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%04d-w%02d", year, week)
+}
+
+func monthlyBucket(t time.Time) string {
+	return fmt.Sprintf("%04d-%02d", t.Year(), t.Month())
+}
+
+func yearlyBucket(t time.Time) string {
+	return fmt.Sprintf("%04d", t.Year())
+}
+
+// Plan computes which of dest's backup sets policy would keep and which
+// it would remove, without touching the filesystem - the basis for both
+// Prune and a --dry-run preview of it.
+func Plan(ctx context.Context, fs dhbfs.FS, dest string, policy RetentionPolicy, getTime func() time.Time) (keep []string, remove []string, err error) {
+	sets, err := listSets(ctx, fs, dest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keepSet := map[string]bool{}
+	for i, s := range sets {
+		if policy.KeepLast > 0 && i < policy.KeepLast {
+			keepSet[s.name] = true
+		}
+	}
+	if policy.KeepWithin > 0 {
+		now := getTime()
+		for _, s := range sets {
+			if now.Sub(s.when) <= policy.KeepWithin {
+				keepSet[s.name] = true
+			}
+		}
+	}
+	for _, bucket := range []struct {
+		quota int
+		key   func(time.Time) string
+	}{
+		{policy.KeepHourly, hourlyBucket},
+		{policy.KeepDaily, dailyBucket},
+		{policy.KeepWeekly, weeklyBucket},
+		{policy.KeepMonthly, monthlyBucket},
+		{policy.KeepYearly, yearlyBucket},
+	} {
+		for name := range bucketKeeper(sets, bucket.quota, bucket.key) {
+			keepSet[name] = true
+		}
+	}
+
+	for _, s := range sets {
+		if keepSet[s.name] {
+			keep = append(keep, s.name)
+		} else {
+			remove = append(remove, s.name)
+		}
+	}
+	return keep, remove, nil
+}
+
+// Prune applies policy to dest's backup sets, deleting the ones it
+// doesn't keep and returning their names, so callers that want a
+// dry-run should call Plan instead and never call Prune at all.
+//
+// Within each set being deleted, files still hard-linked
+// elsewhere are removed first; files already down to their last link -
+// whose removal actually frees the data - are removed last, so a prune
+// interrupted partway through has destroyed as little irreplaceable
+// data as possible.
+func Prune(ctx context.Context, fs dhbfs.FS, dest string, policy RetentionPolicy, getTime func() time.Time) (removed []string, err error) {
+	_, remove, err := Plan(ctx, fs, dest, policy, getTime)
+	if err != nil {
+		return nil, err
+	}
+	for _, name := range remove {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if err := removeSet(ctx, fs, dest, name); err != nil {
+			return removed, fmt.Errorf("failed to prune set %v: %w", name, err)
+		}
+		removed = append(removed, name)
+	}
+	return removed, nil
+}
+
+// removeSet deletes setName's folder and its manifest.
+func removeSet(ctx context.Context, fs dhbfs.FS, dest string, setName string) error {
+	if err := removeTree(ctx, fs, filepath.Join(dest, setName)); err != nil {
+		return err
+	}
+	if err := fs.Remove(manifest.Path(dest, setName)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// removeTree deletes every file and folder under root. Files that still
+// have another hard link elsewhere are unlinked first; files on their
+// last link - the ones whose removal actually frees the data - are
+// unlinked last. Folders are removed deepest-first once they're empty.
+func removeTree(ctx context.Context, fs dhbfs.FS, root string) error {
+	var files, dirs []string
+	if err := walkTree(ctx, fs, root, &files, &dirs); err != nil {
+		return err
+	}
+
+	counter, _ := fs.(dhbfs.LinkCounter)
+	var shared, lastLink []string
+	for _, f := range files {
+		nlinks := 1
+		if counter != nil {
+			n, err := counter.Nlinks(f)
+			if err != nil {
+				return err
+			}
+			nlinks = n
+		}
+		if nlinks > 1 {
+			shared = append(shared, f)
+		} else {
+			lastLink = append(lastLink, f)
+		}
+	}
+
+	for _, group := range [][]string{shared, lastLink} {
+		for _, f := range group {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if err := fs.Remove(f); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := len(dirs) - 1; i >= 0; i-- {
+		if err := fs.Remove(dirs[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkTree records every folder under (and including) dir in dirs,
+// outermost first, and every file in files.
+func walkTree(ctx context.Context, fs dhbfs.FS, dir string, files *[]string, dirs *[]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	*dirs = append(*dirs, dir)
+	contents, err := fs.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, item := range contents {
+		itemPath := filepath.Join(dir, item.Name())
+		if item.IsDir() {
+			if err := walkTree(ctx, fs, itemPath, files, dirs); err != nil {
+				return err
+			}
+			continue
+		}
+		*files = append(*files, itemPath)
+	}
+	return nil
+}
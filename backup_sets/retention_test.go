@@ -0,0 +1,131 @@
+package backup_sets
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/test_helpers"
+)
+
+// makeSet creates an empty backup set at when and returns its name.
+func makeSet(t *testing.T, fs dhbfs.FS, dest string, when time.Time) string {
+	name, err := CreateEmptySet(context.Background(), fs, dest, test_helpers.FixedTime(when))
+	assert.NoError(t, err)
+	return name
+}
+
+func TestPlanKeepsLastN(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	dest := "/backups"
+	assert.NoError(t, dhbfs.MkdirAll(fs, dest, os.ModePerm))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	var names []string
+	for i := 0; i < 5; i++ {
+		names = append(names, makeSet(t, fs, dest, base.Add(time.Duration(i)*time.Hour)))
+	}
+
+	keep, remove, err := Plan(context.Background(), fs, dest, RetentionPolicy{KeepLast: 2}, test_helpers.FixedTime(base.Add(10*time.Hour)))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{names[3], names[4]}, keep)
+	assert.ElementsMatch(t, []string{names[0], names[1], names[2]}, remove)
+}
+
+func TestPlanKeepsOneWithinEachBucket(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	dest := "/backups"
+	assert.NoError(t, dhbfs.MkdirAll(fs, dest, os.ModePerm))
+
+	day1 := makeSet(t, fs, dest, time.Date(2024, 1, 1, 9, 0, 0, 0, time.Local))
+	day1Later := makeSet(t, fs, dest, time.Date(2024, 1, 1, 21, 0, 0, 0, time.Local))
+	day2 := makeSet(t, fs, dest, time.Date(2024, 1, 2, 9, 0, 0, 0, time.Local))
+
+	now := time.Date(2024, 1, 3, 0, 0, 0, 0, time.Local)
+	keep, remove, err := Plan(context.Background(), fs, dest, RetentionPolicy{KeepDaily: 2}, test_helpers.FixedTime(now))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{day2, day1Later}, keep)
+	assert.ElementsMatch(t, []string{day1}, remove)
+}
+
+func TestPlanKeepWithinOverridesAge(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	dest := "/backups"
+	assert.NoError(t, dhbfs.MkdirAll(fs, dest, os.ModePerm))
+
+	now := time.Date(2024, 1, 10, 0, 0, 0, 0, time.Local)
+	recent := makeSet(t, fs, dest, now.Add(-time.Hour))
+	old := makeSet(t, fs, dest, now.Add(-30*24*time.Hour))
+
+	keep, remove, err := Plan(context.Background(), fs, dest, RetentionPolicy{KeepWithin: 24 * time.Hour}, test_helpers.FixedTime(now))
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{recent}, keep)
+	assert.ElementsMatch(t, []string{old}, remove)
+}
+
+func TestPruneDeletesSetsNotKept(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	dest := "/backups"
+	assert.NoError(t, dhbfs.MkdirAll(fs, dest, os.ModePerm))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	oldSet := makeSet(t, fs, dest, base)
+	newSet := makeSet(t, fs, dest, base.Add(time.Hour))
+
+	oldFile := filepath.Join(dest, oldSet, "file.txt")
+	w, err := fs.Create(oldFile)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("data"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	removed, err := Prune(context.Background(), fs, dest, RetentionPolicy{KeepLast: 1}, test_helpers.FixedTime(base.Add(2*time.Hour)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{oldSet}, removed)
+
+	_, err = fs.Stat(filepath.Join(dest, oldSet))
+	assert.True(t, os.IsNotExist(err))
+	_, err = fs.Stat(filepath.Join(dest, newSet))
+	assert.NoError(t, err)
+}
+
+func TestPruneKeepsSharedFileInSurvivingSet(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	dest := "/backups"
+	assert.NoError(t, dhbfs.MkdirAll(fs, dest, os.ModePerm))
+
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	oldSet := makeSet(t, fs, dest, base)
+	newSet := makeSet(t, fs, dest, base.Add(time.Hour))
+
+	sharedOld := filepath.Join(dest, oldSet, "shared.txt")
+	sharedNew := filepath.Join(dest, newSet, "shared.txt")
+	w, err := fs.Create(sharedOld)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("shared"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+	assert.NoError(t, fs.Link(sharedOld, sharedNew))
+
+	uniqueOld := filepath.Join(dest, oldSet, "unique.txt")
+	w, err = fs.Create(uniqueOld)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("unique"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	removed, err := Prune(context.Background(), fs, dest, RetentionPolicy{KeepLast: 1}, test_helpers.FixedTime(base.Add(2*time.Hour)))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{oldSet}, removed)
+
+	// the surviving set's copy of the shared file is untouched
+	_, err = fs.Stat(sharedNew)
+	assert.NoError(t, err)
+	// the whole pruned set, unique file included, is gone
+	_, err = fs.Stat(filepath.Join(dest, oldSet))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,82 @@
+// Package dhbfs defines the filesystem surface that dhcopy and
+// backup_sets need, modelled on afero's Fs interface. OsFS wraps the
+// real filesystem (today's behaviour); MemFS is an in-memory backend for
+// fast, deterministic unit tests. Defining the dependency as an interface
+// also unblocks future remote backends (SFTP, S3, ...) that implement it.
+package dhbfs
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// File is the subset of *os.File that callers in this repo need.
+type File interface {
+	io.Reader
+	io.Writer
+	io.Closer
+}
+
+// FileInfo mirrors the handful of os.FileInfo fields callers use.
+type FileInfo interface {
+	Name() string
+	Size() int64
+	IsDir() bool
+	ModTime() time.Time
+}
+
+// DirEntry is the per-item result of FS.ReadDir. It carries the same
+// information as FileInfo, so it's just an alias.
+type DirEntry = FileInfo
+
+// FS is the filesystem dhcopy and backup_sets are written against,
+// rather than calling the os package directly.
+type FS interface {
+	Open(name string) (File, error)
+	Create(name string) (File, error)
+	Mkdir(name string, perm os.FileMode) error
+	ReadDir(name string) ([]DirEntry, error)
+	Stat(name string) (FileInfo, error)
+	Remove(name string) error
+}
+
+// Linker is an optional FS capability: not every backend can hard-link
+// (a future SFTP or S3 FS, for instance), so callers probe for it with a
+// type assertion - `linker, ok := fs.(dhbfs.Linker)` - and fall back to a
+// plain copy when it's absent.
+type Linker interface {
+	Link(oldname, newname string) error
+}
+
+// LinkCounter is an optional FS capability alongside Linker: it reports
+// how many hard links currently point at name's data, so a caller doing
+// destructive cleanup (see backup_sets.Prune) can tell which removals
+// are still backed up elsewhere and which are the last copy. Probe for
+// it the same way - `counter, ok := fs.(dhbfs.LinkCounter)` - and assume
+// every file is its own last copy when it's absent.
+type LinkCounter interface {
+	Nlinks(name string) (int, error)
+}
+
+// MkdirAll creates dir and any missing parents, in terms of repeated
+// Mkdir calls, the same way os.MkdirAll is built on top of os.Mkdir.
+func MkdirAll(fs FS, dir string, perm os.FileMode) error {
+	dir = cleanPath(dir)
+	if dir == "/" || dir == "." {
+		return nil
+	}
+	if info, err := fs.Stat(dir); err == nil {
+		if !info.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: dir, Err: os.ErrExist}
+		}
+		return nil
+	}
+	if err := MkdirAll(fs, parentOf(dir), perm); err != nil {
+		return err
+	}
+	if err := fs.Mkdir(dir, perm); err != nil && !os.IsExist(err) {
+		return err
+	}
+	return nil
+}
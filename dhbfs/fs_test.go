@@ -0,0 +1,23 @@
+package dhbfs
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMkdirAllCreatesMissingParents(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, MkdirAll(fs, "/a/b/c", os.ModePerm))
+
+	info, err := fs.Stat("/a/b/c")
+	assert.NoError(t, err)
+	assert.True(t, info.IsDir())
+}
+
+func TestMkdirAllIsIdempotent(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, MkdirAll(fs, "/a/b", os.ModePerm))
+	assert.NoError(t, MkdirAll(fs, "/a/b", os.ModePerm))
+}
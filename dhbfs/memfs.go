@@ -0,0 +1,208 @@
+package dhbfs
+
+import (
+	"io"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemFS is an in-memory FS, useful for fast, deterministic tests that
+// don't need to touch the real filesystem. It also implements Linker:
+// Link aliases the destination path to the same backing data as the
+// source, so two "hard-linked" MemFS paths see each other's writes.
+type MemFS struct {
+	mu    sync.Mutex
+	dirs  map[string]bool
+	files map[string]*memFileData
+}
+
+type memFileData struct {
+	data    []byte
+	modTime time.Time
+}
+
+// NewMemFS returns an empty MemFS with just a root directory.
+func NewMemFS() *MemFS {
+	return &MemFS{
+		dirs:  map[string]bool{"/": true},
+		files: make(map[string]*memFileData),
+	}
+}
+
+func (m *MemFS) Mkdir(name string, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := cleanPath(name)
+	if m.dirs[p] {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrExist}
+	}
+	if !m.dirs[path.Dir(p)] {
+		return &os.PathError{Op: "mkdir", Path: name, Err: os.ErrNotExist}
+	}
+	m.dirs[p] = true
+	return nil
+}
+
+func (m *MemFS) Create(name string) (File, error) {
+	m.mu.Lock()
+	p := cleanPath(name)
+	f, ok := m.files[p]
+	if ok {
+		// truncate in place, so paths linked to this one see it too
+		f.data = nil
+		f.modTime = time.Now()
+	} else {
+		f = &memFileData{modTime: time.Now()}
+		m.files[p] = f
+	}
+	m.mu.Unlock()
+	return &memOpenFile{file: f}, nil
+}
+
+func (m *MemFS) Open(name string) (File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := cleanPath(name)
+	f, ok := m.files[p]
+	if !ok {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+	return &memOpenFile{file: f}, nil
+}
+
+func (m *MemFS) Stat(name string) (FileInfo, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.statLocked(cleanPath(name))
+}
+
+func (m *MemFS) statLocked(p string) (FileInfo, error) {
+	if m.dirs[p] {
+		return &memFileInfo{name: path.Base(p), isDir: true}, nil
+	}
+	if f, ok := m.files[p]; ok {
+		return &memFileInfo{name: path.Base(p), size: int64(len(f.data)), modTime: f.modTime}, nil
+	}
+	return nil, &os.PathError{Op: "stat", Path: p, Err: os.ErrNotExist}
+}
+
+func (m *MemFS) ReadDir(name string) ([]DirEntry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := cleanPath(name)
+	if !m.dirs[p] {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	var names []string
+	for d := range m.dirs {
+		if d != p && path.Dir(d) == p {
+			names = append(names, d)
+		}
+	}
+	for f := range m.files {
+		if path.Dir(f) == p {
+			names = append(names, f)
+		}
+	}
+	sort.Strings(names)
+
+	entries := make([]DirEntry, len(names))
+	for i, n := range names {
+		info, err := m.statLocked(n)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = info
+	}
+	return entries, nil
+}
+
+func (m *MemFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := cleanPath(name)
+	if _, ok := m.files[p]; ok {
+		delete(m.files, p)
+		return nil
+	}
+	if m.dirs[p] {
+		delete(m.dirs, p)
+		return nil
+	}
+	return &os.PathError{Op: "remove", Path: name, Err: os.ErrNotExist}
+}
+
+// Link implements the optional Linker capability for MemFS: newname
+// becomes another name for oldname's backing data, so writes through one
+// path are visible through the other, just like a real hard link.
+func (m *MemFS) Link(oldname, newname string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	op := cleanPath(oldname)
+	f, ok := m.files[op]
+	if !ok {
+		return &os.PathError{Op: "link", Path: oldname, Err: os.ErrNotExist}
+	}
+	m.files[cleanPath(newname)] = f
+	return nil
+}
+
+// Nlinks implements the optional LinkCounter capability for MemFS: since
+// Link just aliases two paths to the same *memFileData, the link count
+// is however many paths currently point at name's one.
+func (m *MemFS) Nlinks(name string) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	p := cleanPath(name)
+	f, ok := m.files[p]
+	if !ok {
+		return 0, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+	}
+	count := 0
+	for _, other := range m.files {
+		if other == f {
+			count++
+		}
+	}
+	return count, nil
+}
+
+type memFileInfo struct {
+	name    string
+	size    int64
+	isDir   bool
+	modTime time.Time
+}
+
+func (i *memFileInfo) Name() string       { return i.name }
+func (i *memFileInfo) Size() int64        { return i.size }
+func (i *memFileInfo) IsDir() bool        { return i.isDir }
+func (i *memFileInfo) ModTime() time.Time { return i.modTime }
+
+type memOpenFile struct {
+	file *memFileData
+	pos  int
+}
+
+func (f *memOpenFile) Read(p []byte) (int, error) {
+	if f.pos >= len(f.file.data) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.file.data[f.pos:])
+	f.pos += n
+	return n, nil
+}
+
+func (f *memOpenFile) Write(p []byte) (int, error) {
+	f.file.data = append(f.file.data, p...)
+	f.file.modTime = time.Now()
+	return len(p), nil
+}
+
+func (f *memOpenFile) Close() error {
+	return nil
+}
@@ -0,0 +1,149 @@
+package dhbfs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemFSCreateAndOpen(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/backups", os.ModePerm))
+
+	w, err := fs.Create("/backups/file.txt")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hello go"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := fs.Open("/backups/file.txt")
+	assert.NoError(t, err)
+	contents, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello go", string(contents))
+}
+
+func TestMemFSOpenMissingFile(t *testing.T) {
+	fs := NewMemFS()
+	_, err := fs.Open("/nope.txt")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMemFSMkdirRequiresParent(t *testing.T) {
+	fs := NewMemFS()
+	err := fs.Mkdir("/a/b", os.ModePerm)
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NoError(t, fs.Mkdir("/a", os.ModePerm))
+	assert.NoError(t, fs.Mkdir("/a/b", os.ModePerm))
+}
+
+func TestMemFSReadDirListsFilesAndDirs(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	assert.NoError(t, fs.Mkdir("/root/sub", os.ModePerm))
+	_, err := fs.Create("/root/file.txt")
+	assert.NoError(t, err)
+
+	entries, err := fs.ReadDir("/root")
+	assert.NoError(t, err)
+	assert.Len(t, entries, 2)
+
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"sub", "file.txt"}, names)
+}
+
+func TestMemFSStat(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	w, err := fs.Create("/root/file.txt")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("12345"))
+	assert.NoError(t, err)
+
+	info, err := fs.Stat("/root/file.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), info.Size())
+	assert.False(t, info.IsDir())
+
+	dirInfo, err := fs.Stat("/root")
+	assert.NoError(t, err)
+	assert.True(t, dirInfo.IsDir())
+}
+
+func TestMemFSLinkSharesData(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	w, err := fs.Create("/root/a.txt")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("shared"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, fs.Link("/root/a.txt", "/root/b.txt"))
+
+	r, err := fs.Open("/root/b.txt")
+	assert.NoError(t, err)
+	contents, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "shared", string(contents))
+
+	var linker Linker = fs
+	assert.NotNil(t, linker)
+}
+
+func TestMemFSNlinksCountsAliasedPaths(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	w, err := fs.Create("/root/a.txt")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("shared"))
+	assert.NoError(t, err)
+
+	n, err := fs.Nlinks("/root/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	assert.NoError(t, fs.Link("/root/a.txt", "/root/b.txt"))
+
+	n, err = fs.Nlinks("/root/a.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	var counter LinkCounter = fs
+	assert.NotNil(t, counter)
+}
+
+func TestMemFSRemove(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	_, err := fs.Create("/root/a.txt")
+	assert.NoError(t, err)
+
+	assert.NoError(t, fs.Remove("/root/a.txt"))
+	_, err = fs.Open("/root/a.txt")
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestMemFSReadReturnsEOFAtEnd(t *testing.T) {
+	fs := NewMemFS()
+	assert.NoError(t, fs.Mkdir("/root", os.ModePerm))
+	w, err := fs.Create("/root/a.txt")
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hi"))
+	assert.NoError(t, err)
+
+	r, err := fs.Open("/root/a.txt")
+	assert.NoError(t, err)
+	buf := make([]byte, 10)
+	n, err := r.Read(buf)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	_, err = r.Read(buf)
+	assert.Equal(t, io.EOF, err)
+}
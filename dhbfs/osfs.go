@@ -0,0 +1,67 @@
+package dhbfs
+
+import (
+	"io/ioutil"
+	"os"
+	"syscall"
+)
+
+// OsFS is an FS backed by the real filesystem.
+type OsFS struct{}
+
+// NewOsFS returns an FS that delegates straight to the os package.
+func NewOsFS() *OsFS {
+	return &OsFS{}
+}
+
+func (OsFS) Open(name string) (File, error) {
+	return os.Open(name)
+}
+
+func (OsFS) Create(name string) (File, error) {
+	return os.Create(name)
+}
+
+func (OsFS) Mkdir(name string, perm os.FileMode) error {
+	return os.Mkdir(name, perm)
+}
+
+func (OsFS) Stat(name string) (FileInfo, error) {
+	return os.Stat(name)
+}
+
+func (OsFS) Remove(name string) error {
+	return os.Remove(name)
+}
+
+// Link implements the optional Linker capability for OsFS.
+func (OsFS) Link(oldname, newname string) error {
+	return os.Link(oldname, newname)
+}
+
+// Nlinks implements the optional LinkCounter capability for OsFS, reading
+// the real link count out of the platform-specific stat_t that os.FileInfo
+// wraps.
+func (OsFS) Nlinks(name string) (int, error) {
+	info, err := os.Stat(name)
+	if err != nil {
+		return 0, err
+	}
+	stat, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 1, nil
+	}
+	return int(stat.Nlink), nil
+}
+
+func (OsFS) ReadDir(name string) ([]DirEntry, error) {
+	infos, err := ioutil.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]DirEntry, len(infos))
+	for i, info := range infos {
+		entries[i] = info
+	}
+	return entries, nil
+}
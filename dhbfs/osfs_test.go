@@ -0,0 +1,63 @@
+package dhbfs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOsFSRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "dhbfs-osfs-")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	fs := NewOsFS()
+	subdir := filepath.Join(dir, "sub")
+	assert.NoError(t, fs.Mkdir(subdir, os.ModePerm))
+
+	filePath := filepath.Join(subdir, "file.txt")
+	w, err := fs.Create(filePath)
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("hello go"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	r, err := fs.Open(filePath)
+	assert.NoError(t, err)
+	contents, err := ioutil.ReadAll(r)
+	assert.NoError(t, err)
+	assert.Equal(t, "hello go", string(contents))
+
+	entries, err := fs.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "sub", entries[0].Name())
+	assert.True(t, entries[0].IsDir())
+
+	info, err := fs.Stat(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len("hello go")), info.Size())
+
+	var linker Linker = fs
+	linkPath := filepath.Join(subdir, "link.txt")
+	assert.NoError(t, linker.Link(filePath, linkPath))
+	linkedInfo, err := fs.Stat(linkPath)
+	assert.NoError(t, err)
+	assert.Equal(t, info.Size(), linkedInfo.Size())
+
+	var counter LinkCounter = fs
+	n, err := counter.Nlinks(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+
+	assert.NoError(t, fs.Remove(linkPath))
+	_, err = fs.Stat(linkPath)
+	assert.True(t, os.IsNotExist(err))
+
+	n, err = counter.Nlinks(filePath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+}
@@ -0,0 +1,16 @@
+package dhbfs
+
+import (
+	"path"
+	"path/filepath"
+)
+
+// cleanPath normalises a filesystem path to slash-separated form so both
+// OsFS (native paths) and MemFS (synthetic paths) agree on it.
+func cleanPath(p string) string {
+	return path.Clean(filepath.ToSlash(p))
+}
+
+func parentOf(p string) string {
+	return path.Dir(cleanPath(p))
+}
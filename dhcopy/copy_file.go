@@ -1,29 +1,55 @@
 package dhcopy
 
 import (
+	"context"
 	"io"
 	"log"
-	"os"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
 )
 
-func CopyFile(source string, dest string) {
+// CopyFile copies source to dest through fs, aborting promptly with
+// ctx.Err() if ctx is cancelled mid-copy, and returns the number of bytes
+// copied.
+func CopyFile(ctx context.Context, fs dhbfs.FS, source string, dest string) (bytesWritten int64, err error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
 	log.Printf("copying %v to : %v\n", source, dest)
 
-	srcFile, err := os.Open(source)
+	srcFile, err := fs.Open(source)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 	defer srcFile.Close()
 
-	destFile, err := os.Create(dest)
+	destFile, err := fs.Create(dest)
 	if err != nil {
-		log.Fatal(err)
+		return 0, err
 	}
 	defer destFile.Close()
 
-	bytesWritten, err := io.Copy(destFile, srcFile)
+	bytesWritten, err = io.Copy(destFile, &contextReader{ctx: ctx, r: srcFile})
 	if err != nil {
-		log.Fatal(err)
+		// don't leave a half-written file behind
+		destFile.Close()
+		fs.Remove(dest)
+		return bytesWritten, err
 	}
 	log.Printf("%v bytes copied\n", bytesWritten)
+	return bytesWritten, nil
+}
+
+// contextReader wraps an io.Reader so a long-running io.Copy notices
+// context cancellation between reads instead of running to completion.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
 }
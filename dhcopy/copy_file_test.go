@@ -1,7 +1,9 @@
 package dhcopy
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
 	"github.com/timabell/disk-hog-backup/test_helpers"
 	"io/ioutil"
 	"log"
@@ -27,11 +29,32 @@ func TestCopy(t *testing.T) {
 
 	destFileName := filepath.Join(dest, theFile)
 
-	CopyFile(sourceFileName, destFileName)
+	bytesWritten, err := CopyFile(context.Background(), dhbfs.NewOsFS(), sourceFileName, destFileName)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(theText)), bytesWritten)
 
 	checkFileCopied(t, dest)
 }
 
+func TestCopyFileHonoursCancelledContext(t *testing.T) {
+	sourceFolder := test_helpers.CreateTmpFolder("orig")
+	defer os.RemoveAll(sourceFolder)
+	dest := test_helpers.CreateTmpFolder("backups")
+	defer os.RemoveAll(dest)
+
+	sourceFileName := filepath.Join(sourceFolder, theFile)
+	if err := ioutil.WriteFile(sourceFileName, []byte(theText), 0666); err != nil {
+		log.Fatal(err)
+	}
+	destFileName := filepath.Join(dest, theFile)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CopyFile(ctx, dhbfs.NewOsFS(), sourceFileName, destFileName)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
 func checkFileCopied(t *testing.T, dest string) {
 	destFileName := filepath.Join(dest, theFile)
 	backupContents, err := ioutil.ReadFile(destFileName)
@@ -1,35 +1,77 @@
 package dhcopy
 
 import (
-	"io/ioutil"
+	"context"
 	"log"
 	"os"
 	"path/filepath"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/filter"
+	"github.com/timabell/disk-hog-backup/reporter"
 )
 
-func CopyFolder(source string, dest string) error {
+// CopyFolder recursively copies source into dest through fs, skipping any
+// paths excluded by filterOpt. filterOpt may be nil, in which case nothing
+// is excluded. It returns ctx.Err() promptly if ctx is cancelled mid-walk.
+func CopyFolder(ctx context.Context, fs dhbfs.FS, source string, dest string, filterOpt *filter.FilterOpt) error {
+	matcher, err := filter.New(filterOpt)
+	if err != nil {
+		return err
+	}
+	return copyFolder(ctx, fs, source, source, dest, matcher)
+}
+
+func copyFolder(ctx context.Context, fs dhbfs.FS, root string, source string, dest string, matcher *filter.Matcher) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	rep := reporter.FromContext(ctx)
 	log.Printf("backing up folder %v into %v\n", source, dest)
-	contents, err := ioutil.ReadDir(source)
+	contents, err := fs.ReadDir(source)
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
 
 	for _, item := range contents {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		itemPath := filepath.Join(source, item.Name())
+		relPath, err := filepath.Rel(root, itemPath)
+		if err != nil {
+			return err
+		}
+
 		if item.IsDir() {
+			if matcher.SkipDir(relPath) {
+				log.Printf("skipping excluded folder %v\n", relPath)
+				continue
+			}
 			destFolder := filepath.Join(dest, item.Name())
-			err := os.Mkdir(destFolder, os.ModePerm)
-			if err != nil {
-				log.Fatal(err)
+			if err := fs.Mkdir(destFolder, os.ModePerm); err != nil {
+				return err
 			}
-			dirPath := filepath.Join(source, item.Name())
-			if err := CopyFolder(dirPath, destFolder); err != nil {
+			if err := copyFolder(ctx, fs, root, itemPath, destFolder, matcher); err != nil {
 				return err
 			}
 			continue
 		}
-		itemPath := filepath.Join(source, item.Name())
+
+		if matcher.ExcludesPath(relPath, false) {
+			log.Printf("skipping excluded file %v\n", relPath)
+			continue
+		}
 		destFile := filepath.Join(dest, item.Name())
-		CopyFile(itemPath, destFile)
+		rep.FileStarted(relPath, item.Size())
+		bytesCopied, err := CopyFile(ctx, fs, itemPath, destFile)
+		if err != nil {
+			rep.Error(relPath, err)
+			return err
+		}
+		rep.FileFinished(relPath, bytesCopied, false)
 	}
 	return nil
 }
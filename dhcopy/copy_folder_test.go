@@ -1,7 +1,10 @@
 package dhcopy
 
 import (
+	"context"
 	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/filter"
 	"github.com/timabell/disk-hog-backup/test_helpers"
 	"io/ioutil"
 	"os"
@@ -29,7 +32,7 @@ func TestCopiesFiles(t *testing.T) {
 	dest := test_helpers.CreateTmpFolder(backupFolderName)
 	defer os.RemoveAll(dest)
 
-	CopyFolder(source, dest)
+	CopyFolder(context.Background(), dhbfs.NewOsFS(), source, dest, nil)
 
 	// Just a quick check that recursion is including files.
 	// Full testing of files is is in the file copier tests.
@@ -51,11 +54,77 @@ func TestCopyEmptyFolder(t *testing.T) {
 	dest := test_helpers.CreateTmpFolder(backupFolderName)
 	defer os.RemoveAll(dest)
 
-	CopyFolder(source, dest)
+	CopyFolder(context.Background(), dhbfs.NewOsFS(), source, dest, nil)
 
 	checkEmptyFolderCopied(t, dest)
 }
 
+func TestCopyFolderExcludesMatchingFiles(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	test_helpers.MakeTestFile(filepath.Join(source, "keep.txt"), "keep me")
+	test_helpers.MakeTestFile(filepath.Join(source, "secret.tmp"), "drop me")
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	err := CopyFolder(context.Background(), dhbfs.NewOsFS(), source, dest, &filter.FilterOpt{ExcludePatterns: []string{"*.tmp"}})
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dest, "keep.txt"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(dest, "secret.tmp"))
+	assert.True(t, os.IsNotExist(err), "excluded file should not have been copied")
+}
+
+func TestCopyFolderSkipsExcludedDirectories(t *testing.T) {
+	source := createSource()
+	defer os.RemoveAll(source)
+	logsPath := filepath.Join(source, "logs")
+	if err := os.MkdirAll(logsPath, os.ModePerm); err != nil {
+		panic(err)
+	}
+	test_helpers.MakeTestFile(filepath.Join(logsPath, "today.log"), "noisy")
+	dest := test_helpers.CreateTmpFolder(backupFolderName)
+	defer os.RemoveAll(dest)
+
+	err := CopyFolder(context.Background(), dhbfs.NewOsFS(), source, dest, &filter.FilterOpt{ExcludePatterns: []string{"logs/**"}})
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(dest, "logs"))
+	assert.True(t, os.IsNotExist(err), "excluded folder should not have been created")
+}
+
+func TestCopyFolderAgainstMemFS(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	assert.NoError(t, fs.Mkdir("/source/sub", os.ModePerm))
+	writeMemFile(t, fs, "/source/top.txt", "top level")
+	writeMemFile(t, fs, "/source/sub/deep.txt", "deep file")
+	assert.NoError(t, fs.Mkdir("/dest", os.ModePerm))
+
+	err := CopyFolder(context.Background(), fs, "/source", "/dest", nil)
+	assert.NoError(t, err)
+
+	assertMemFileContents(t, fs, "/dest/top.txt", "top level")
+	assertMemFileContents(t, fs, "/dest/sub/deep.txt", "deep file")
+}
+
+func writeMemFile(t *testing.T, fs dhbfs.FS, path string, contents string) {
+	f, err := fs.Create(path)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte(contents))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+}
+
+func assertMemFileContents(t *testing.T, fs dhbfs.FS, path string, expected string) {
+	f, err := fs.Open(path)
+	assert.NoError(t, err)
+	contents, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, string(contents))
+}
+
 func checkEmptyFolderCopied(t *testing.T, dest string) {
 	dirPath := filepath.Join(dest, emptyFolder)
 	dir, err := ioutil.ReadDir(dirPath)
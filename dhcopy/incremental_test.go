@@ -0,0 +1,134 @@
+package dhcopy
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/manifest"
+)
+
+func TestCopyFolderIncrementalFirstRunCopiesEverything(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeMemFile(t, fs, "/source/a.txt", "hello")
+	assert.NoError(t, fs.Mkdir("/dest1", os.ModePerm))
+
+	m, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest1", "", nil, nil)
+	assert.NoError(t, err)
+
+	assertMemFileContents(t, fs, "/dest1/a.txt", "hello")
+	entry, ok := m["a.txt"]
+	assert.True(t, ok)
+	assert.Equal(t, int64(5), entry.Size)
+	assert.NotEmpty(t, entry.Sha256)
+}
+
+func TestCopyFolderIncrementalLinksUnchangedFiles(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeMemFile(t, fs, "/source/unchanged.txt", "same forever")
+	writeMemFile(t, fs, "/source/changed.txt", "original")
+	assert.NoError(t, fs.Mkdir("/dest1", os.ModePerm))
+
+	firstManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest1", "", nil, nil)
+	assert.NoError(t, err)
+
+	// changed.txt is rewritten between runs; unchanged.txt is left alone.
+	writeMemFile(t, fs, "/source/changed.txt", "updated")
+
+	assert.NoError(t, fs.Mkdir("/dest2", os.ModePerm))
+	secondManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest2", "/dest1", firstManifest, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, firstManifest["unchanged.txt"], secondManifest["unchanged.txt"])
+	assert.NotEqual(t, firstManifest["changed.txt"].Sha256, secondManifest["changed.txt"].Sha256)
+
+	assertMemFileContents(t, fs, "/dest2/unchanged.txt", "same forever")
+	assertMemFileContents(t, fs, "/dest2/changed.txt", "updated")
+
+	// unchanged.txt in /dest1 and /dest2 share one copy of the data.
+	n, err := fs.Nlinks("/dest2/unchanged.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestCopyFolderIncrementalKeepsLinkWhenOnlyMtimeChanged(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeMemFile(t, fs, "/source/touched.txt", "same content")
+	assert.NoError(t, fs.Mkdir("/dest1", os.ModePerm))
+	writeMemFile(t, fs, "/dest1/touched.txt", "same content")
+
+	sum, err := manifest.Hash(context.Background(), fs, "/dest1/touched.txt")
+	assert.NoError(t, err)
+	prevManifest := manifest.Manifest{
+		"touched.txt": {Size: 12, ModTime: time.Time{}, Sha256: sum},
+	}
+
+	assert.NoError(t, fs.Mkdir("/dest2", os.ModePerm))
+	newManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest2", "/dest1", prevManifest, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, sum, newManifest["touched.txt"].Sha256)
+	assertMemFileContents(t, fs, "/dest2/touched.txt", "same content")
+
+	n, err := fs.Nlinks("/dest2/touched.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+}
+
+func TestCopyFolderIncrementalFallsBackToCopyWhenPrevFileMissing(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeMemFile(t, fs, "/source/pruned.txt", "still here")
+	assert.NoError(t, fs.Mkdir("/dest1", os.ModePerm))
+
+	// the previous manifest says this file is unchanged, but its set was
+	// partially pruned since, so /dest1/pruned.txt no longer exists.
+	info, err := fs.Stat("/source/pruned.txt")
+	assert.NoError(t, err)
+	prevManifest := manifest.Manifest{
+		"pruned.txt": {Size: info.Size(), ModTime: info.ModTime(), Sha256: "stale"},
+	}
+
+	assert.NoError(t, fs.Mkdir("/dest2", os.ModePerm))
+	newManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest2", "/dest1", prevManifest, nil)
+	assert.NoError(t, err)
+
+	assertMemFileContents(t, fs, "/dest2/pruned.txt", "still here")
+	assert.NotEqual(t, "stale", newManifest["pruned.txt"].Sha256)
+}
+
+func TestCopyFolderIncrementalFallsBackToCopyWithoutLinker(t *testing.T) {
+	fs := noLinkerFS{dhbfs.NewMemFS()}
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeMemFile(t, fs, "/source/unchanged.txt", "same forever")
+	assert.NoError(t, fs.Mkdir("/dest1", os.ModePerm))
+
+	firstManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest1", "", nil, nil)
+	assert.NoError(t, err)
+
+	assert.NoError(t, fs.Mkdir("/dest2", os.ModePerm))
+	secondManifest, err := CopyFolderIncremental(context.Background(), fs, "/source", "/dest2", "/dest1", firstManifest, nil)
+	assert.NoError(t, err)
+
+	assert.Equal(t, firstManifest["unchanged.txt"].Sha256, secondManifest["unchanged.txt"].Sha256)
+	assertMemFileContents(t, fs, "/dest2/unchanged.txt", "same forever")
+}
+
+// noLinkerFS delegates to a *dhbfs.MemFS without exposing its Link method,
+// simulating a backend (e.g. a future SFTP or S3 FS) that can't hard-link.
+type noLinkerFS struct {
+	inner *dhbfs.MemFS
+}
+
+func (f noLinkerFS) Open(name string) (dhbfs.File, error)          { return f.inner.Open(name) }
+func (f noLinkerFS) Create(name string) (dhbfs.File, error)        { return f.inner.Create(name) }
+func (f noLinkerFS) Mkdir(name string, perm os.FileMode) error     { return f.inner.Mkdir(name, perm) }
+func (f noLinkerFS) ReadDir(name string) ([]dhbfs.DirEntry, error) { return f.inner.ReadDir(name) }
+func (f noLinkerFS) Stat(name string) (dhbfs.FileInfo, error)      { return f.inner.Stat(name) }
+func (f noLinkerFS) Remove(name string) error                      { return f.inner.Remove(name) }
@@ -1,30 +1,177 @@
 package main
 
 import (
+	"context"
 	"flag"
-	"github.com/timabell/disk-hog-backup/dhcopy"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/timabell/disk-hog-backup/backup"
+	"github.com/timabell/disk-hog-backup/backup_sets"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+	"github.com/timabell/disk-hog-backup/filter"
+	"github.com/timabell/disk-hog-backup/reporter"
 )
 
 var source string
 var destination string
+var excludePatterns stringSliceFlag
+var includePatterns stringSliceFlag
+var excludeFrom string
+var prune bool
+var dryRun bool
+var keepLast int
+var keepHourly int
+var keepDaily int
+var keepWeekly int
+var keepMonthly int
+var keepYearly int
+var keepWithin time.Duration
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		runVerify(os.Args[2:])
+		return
+	}
+	runBackup()
+}
+
+func runBackup() {
 	flag.StringVar(&source, "source", "", "source folder to back up")
 	flag.StringVar(&destination, "destination", "", "destination folder for backups")
+	flag.Var(&excludePatterns, "exclude", "gitignore-style pattern to exclude from the backup, can be repeated")
+	flag.Var(&includePatterns, "include", "gitignore-style pattern to re-include from an earlier --exclude, can be repeated")
+	flag.StringVar(&excludeFrom, "exclude-from", "", "path to a .dhbignore-style file of exclude patterns")
+	flag.BoolVar(&prune, "prune", false, "apply the retention policy to --destination and delete the backup sets it doesn't keep, instead of running a backup")
+	flag.BoolVar(&dryRun, "dry-run", false, "with --prune, report which backup sets would be deleted without deleting them")
+	flag.IntVar(&keepLast, "keep-last", 0, "keep this many of the newest backup sets regardless of age")
+	flag.IntVar(&keepHourly, "keep-hourly", 0, "keep the newest backup set in each of this many of the most recent hours")
+	flag.IntVar(&keepDaily, "keep-daily", 0, "keep the newest backup set in each of this many of the most recent days")
+	flag.IntVar(&keepWeekly, "keep-weekly", 0, "keep the newest backup set in each of this many of the most recent weeks")
+	flag.IntVar(&keepMonthly, "keep-monthly", 0, "keep the newest backup set in each of this many of the most recent months")
+	flag.IntVar(&keepYearly, "keep-yearly", 0, "keep the newest backup set in each of this many of the most recent years")
+	flag.DurationVar(&keepWithin, "keep-within", 0, "keep every backup set newer than this long ago")
 	flag.Parse()
-	Backup(source, destination)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		sig := <-sigCh
+		log.Printf("received %v, cancelling backup\n", sig)
+		cancel()
+	}()
+
+	if prune {
+		runPrune(ctx, destination, dryRun)
+		return
+	}
+
+	filterOpt := &filter.FilterOpt{
+		ExcludePatterns:         excludePatterns,
+		IncludePatterns:         includePatterns,
+		ExcludePatternsFromFile: excludeFrom,
+	}
+
+	ctx = reporter.WithReporter(ctx, reporter.NewTerminalReporter())
+
+	if _, err := Backup(ctx, source, destination, filterOpt); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// runPrune applies the retention policy built from the --keep-* flags to
+// --destination. With --dry-run it only reports, via Plan, which sets
+// would be deleted; otherwise it calls Prune and reports what it actually
+// deleted.
+func runPrune(ctx context.Context, dest string, dryRun bool) {
+	if keepLast == 0 && keepHourly == 0 && keepDaily == 0 && keepWeekly == 0 && keepMonthly == 0 && keepYearly == 0 && keepWithin == 0 {
+		log.Fatal("--prune needs at least one --keep-last/--keep-hourly/--keep-daily/--keep-weekly/--keep-monthly/--keep-yearly/--keep-within rule, or it would keep nothing")
+	}
+
+	policy := backup_sets.RetentionPolicy{
+		KeepLast:    keepLast,
+		KeepHourly:  keepHourly,
+		KeepDaily:   keepDaily,
+		KeepWeekly:  keepWeekly,
+		KeepMonthly: keepMonthly,
+		KeepYearly:  keepYearly,
+		KeepWithin:  keepWithin,
+	}
+	fs := dhbfs.NewOsFS()
+
+	if dryRun {
+		_, remove, err := backup_sets.Plan(ctx, fs, dest, policy, time.Now)
+		if err != nil {
+			log.Fatal(err)
+		}
+		for _, setName := range remove {
+			fmt.Printf("would delete %v\n", setName)
+		}
+		return
+	}
+
+	removed, err := backup_sets.Prune(ctx, fs, dest, policy, time.Now)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, setName := range removed {
+		fmt.Printf("deleted %v\n", setName)
+	}
 }
 
-func Backup(source string, dest string) error {
-	err := os.MkdirAll(dest, os.ModePerm)
+// runVerify implements `dhb verify --destination <dest> <set-name>`: it
+// re-hashes every file the named set's manifest recorded and reports any
+// that are missing or no longer match, i.e. bitrot.
+func runVerify(args []string) {
+	verifyFlags := flag.NewFlagSet("verify", flag.ExitOnError)
+	dest := verifyFlags.String("destination", "", "destination folder containing backup sets")
+	verifyFlags.Parse(args)
+
+	if verifyFlags.NArg() < 1 {
+		log.Fatal("usage: dhb verify --destination <dest> <set-name>")
+	}
+	setName := verifyFlags.Arg(0)
+
+	results, err := backup.Verify(context.Background(), dhbfs.NewOsFS(), *dest, setName)
 	if err != nil {
 		log.Fatal(err)
 	}
-	log.Printf("backing up %v into %v\n", source, dest)
-	if err := dhcopy.CopyFolder(source, dest); err != nil {
-		return err
+
+	corrupted := 0
+	for _, result := range results {
+		if result.Status != backup.OK {
+			corrupted++
+		}
+		fmt.Printf("%v: %v\n", result.Status, result.Path)
+	}
+	if corrupted > 0 {
+		log.Fatalf("verify found %v problem(s) in %v\n", corrupted, setName)
 	}
+}
+
+// Backup runs a full backup of source into dest, honouring filterOpt and
+// returning ctx.Err() promptly if ctx is cancelled mid-run.
+func Backup(ctx context.Context, source string, dest string, filterOpt *filter.FilterOpt) (setName string, err error) {
+	return backup.Backup(ctx, dhbfs.NewOsFS(), source, dest, time.Now, filterOpt)
+}
+
+// stringSliceFlag lets a flag (e.g. --exclude) be passed more than once,
+// accumulating each value.
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	*f = append(*f, value)
 	return nil
 }
@@ -0,0 +1,70 @@
+// Package filter provides gitignore-style include/exclude pattern matching
+// for selecting which files and directories a backup run should walk into.
+//
+// The matching rules follow the same semantics as tonistiigi/fsutil and
+// moby/patternmatcher: patterns are matched against the path of each item
+// relative to the root of the backup, "**" matches zero or more path
+// segments, "*" matches within a single segment, a leading "!" re-includes
+// a path an earlier pattern excluded, and a pattern ending in "/" only
+// matches directories.
+package filter
+
+import (
+	"bufio"
+	"os"
+	"strings"
+)
+
+// FilterOpt describes which paths should be skipped (or kept) while walking
+// a backup source tree.
+type FilterOpt struct {
+	IncludePatterns []string
+	ExcludePatterns []string
+
+	// ExcludePatternsFromFile points at a .dhbignore-style file, one pattern
+	// per line, blank lines and lines starting with "#" are ignored.
+	ExcludePatternsFromFile string
+}
+
+// allPatterns returns the exclude patterns (from file, then from the
+// ExcludePatterns field) followed by the include patterns, each forced into
+// a re-inclusion ("!") pattern. Later patterns win, so includes always have
+// the final say over excludes that came before them.
+func (f *FilterOpt) allPatterns() ([]string, error) {
+	var patterns []string
+	if f != nil && f.ExcludePatternsFromFile != "" {
+		fromFile, err := readPatternFile(f.ExcludePatternsFromFile)
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, fromFile...)
+	}
+	if f != nil {
+		patterns = append(patterns, f.ExcludePatterns...)
+		for _, include := range f.IncludePatterns {
+			if !strings.HasPrefix(include, "!") {
+				include = "!" + include
+			}
+			patterns = append(patterns, include)
+		}
+	}
+	return patterns, nil
+}
+
+func readPatternFile(path string) (patterns []string, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, scanner.Err()
+}
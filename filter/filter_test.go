@@ -0,0 +1,28 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludePatternsFromFile(t *testing.T) {
+	dir := t.TempDir()
+	ignoreFile := filepath.Join(dir, ".dhbignore")
+	contents := "# comment\n\n*.tmp\nlogs/**\n"
+	assert.NoError(t, os.WriteFile(ignoreFile, []byte(contents), 0644))
+
+	m, err := New(&FilterOpt{ExcludePatternsFromFile: ignoreFile})
+	assert.NoError(t, err)
+
+	assert.True(t, m.ExcludesPath("foo.tmp", false))
+	assert.True(t, m.ExcludesPath("logs/today.log", false))
+	assert.False(t, m.ExcludesPath("keep.txt", false))
+}
+
+func TestExcludePatternsFromMissingFile(t *testing.T) {
+	_, err := New(&FilterOpt{ExcludePatternsFromFile: "/does/not/exist"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,175 @@
+package filter
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher is a compiled, ordered list of include/exclude patterns ready to
+// be matched against relative paths.
+type Matcher struct {
+	patterns       []*pattern
+	hasReinclusion bool
+}
+
+type pattern struct {
+	raw       string // pattern text with any leading "!" stripped
+	regex     *regexp.Regexp
+	exclusion bool // true = this pattern excludes, false = it re-includes
+	dirOnly   bool // pattern ended in "/"
+}
+
+// New compiles a FilterOpt into a Matcher. A nil FilterOpt (or one with no
+// patterns at all) produces a Matcher that excludes nothing.
+func New(opt *FilterOpt) (*Matcher, error) {
+	raw, err := opt.allPatterns()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Matcher{}
+	for _, p := range raw {
+		compiled, err := compile(p)
+		if err != nil {
+			return nil, err
+		}
+		if !compiled.exclusion {
+			m.hasReinclusion = true
+		}
+		m.patterns = append(m.patterns, compiled)
+	}
+	return m, nil
+}
+
+// ExcludesPath reports whether relPath (slash-separated, relative to the
+// backup source root) is excluded. isDir must be true when relPath is a
+// directory so that directory-only patterns ("foo/") are honoured.
+func (m *Matcher) ExcludesPath(relPath string, isDir bool) bool {
+	if m == nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	excluded := false
+	for _, p := range m.patterns {
+		if p.dirOnly && !isDir {
+			continue
+		}
+		if p.regex.MatchString(relPath) {
+			excluded = p.exclusion
+		}
+	}
+	return excluded
+}
+
+// SkipDir reports whether a directory can be skipped entirely, without
+// descending into it at all. This is only safe when the directory is
+// excluded AND no re-inclusion pattern could possibly match something
+// beneath it - otherwise a pattern like "!logs/keep.txt" underneath an
+// excluded "logs/**" would never get a chance to match.
+func (m *Matcher) SkipDir(relPath string) bool {
+	if m == nil {
+		return false
+	}
+	relPath = filepath.ToSlash(relPath)
+	if !m.ExcludesPath(relPath, true) {
+		return false
+	}
+	if !m.hasReinclusion {
+		return true
+	}
+	prefix := relPath + "/"
+	for _, p := range m.patterns {
+		if p.exclusion {
+			continue
+		}
+		lit := literalPrefix(p.raw)
+		if lit == "" || strings.Contains(p.raw, "**") {
+			// a leading wildcard, or a "**" anywhere in the pattern,
+			// could reach under any directory - assume it might.
+			return false
+		}
+		if strings.HasPrefix(p.raw, prefix) || strings.HasPrefix(prefix, lit) {
+			return false
+		}
+	}
+	return true
+}
+
+// literalPrefix returns the portion of a pattern before its first wildcard,
+// used by SkipDir to decide whether a re-inclusion pattern could reach
+// beneath a given directory.
+func literalPrefix(s string) string {
+	if idx := strings.IndexAny(s, "*?"); idx >= 0 {
+		return s[:idx]
+	}
+	return s
+}
+
+func compile(raw string) (*pattern, error) {
+	p := raw
+	exclusion := true
+	if strings.HasPrefix(p, "!") {
+		exclusion = false
+		p = p[1:]
+	}
+
+	dirOnly := strings.HasSuffix(p, "/") && p != "/"
+	p = strings.TrimSuffix(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	regex, err := patternToRegexp(p)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pattern{
+		raw:       p,
+		regex:     regex,
+		exclusion: exclusion,
+		dirOnly:   dirOnly,
+	}, nil
+}
+
+// patternToRegexp translates a gitignore-style glob into an anchored
+// regexp: "**" matches zero or more path segments, "*" matches within a
+// single segment, "?" matches a single non-separator character.
+func patternToRegexp(p string) (*regexp.Regexp, error) {
+	var out strings.Builder
+	out.WriteString("^")
+
+	runes := []rune(p)
+	for i := 0; i < len(runes); i++ {
+		ch := runes[i]
+		switch {
+		case ch == '*' && i+1 < len(runes) && runes[i+1] == '*':
+			i++
+			switch {
+			case i+1 < len(runes) && runes[i+1] == '/':
+				// "**/" matches zero or more whole leading segments.
+				out.WriteString("(.*/)?")
+				i++
+			case strings.HasSuffix(out.String(), "/"):
+				// a trailing "/**" matches the directory itself as well
+				// as everything beneath it, so SkipDir("logs") can act
+				// on "logs/**" without a file named "logs" underneath.
+				trimmed := strings.TrimSuffix(out.String(), "/")
+				out.Reset()
+				out.WriteString(trimmed)
+				out.WriteString("(/.*)?")
+			default:
+				out.WriteString(".*")
+			}
+		case ch == '*':
+			out.WriteString("[^/]*")
+		case ch == '?':
+			out.WriteString("[^/]")
+		case strings.ContainsRune(`.(){}+|^$\`, ch):
+			out.WriteString(`\` + string(ch))
+		default:
+			out.WriteString(string(ch))
+		}
+	}
+	out.WriteString("$")
+	return regexp.Compile(out.String())
+}
@@ -0,0 +1,84 @@
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExcludesPath(t *testing.T) {
+	m, err := New(&FilterOpt{ExcludePatterns: []string{"*.tmp", "logs/**"}})
+	assert.NoError(t, err)
+
+	assert.True(t, m.ExcludesPath("foo.tmp", false))
+	assert.False(t, m.ExcludesPath("foo.txt", false))
+	assert.True(t, m.ExcludesPath("logs/today.log", false))
+	assert.False(t, m.ExcludesPath("not-logs/today.log", false))
+}
+
+func TestReinclusionOverridesExclude(t *testing.T) {
+	m, err := New(&FilterOpt{
+		ExcludePatterns: []string{"logs/**"},
+		IncludePatterns: []string{"logs/keep.txt"},
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, m.ExcludesPath("logs/today.log", false))
+	assert.False(t, m.ExcludesPath("logs/keep.txt", false))
+}
+
+func TestDirOnlyPatternIgnoresFiles(t *testing.T) {
+	m, err := New(&FilterOpt{ExcludePatterns: []string{"build/"}})
+	assert.NoError(t, err)
+
+	assert.True(t, m.ExcludesPath("build", true))
+	assert.False(t, m.ExcludesPath("build", false))
+}
+
+func TestSkipDirWithoutReinclusion(t *testing.T) {
+	m, err := New(&FilterOpt{ExcludePatterns: []string{"logs/**"}})
+	assert.NoError(t, err)
+
+	assert.True(t, m.SkipDir("logs"))
+	assert.False(t, m.SkipDir("src"))
+}
+
+func TestSkipDirWithReinclusionBeneath(t *testing.T) {
+	m, err := New(&FilterOpt{
+		ExcludePatterns: []string{"logs/**"},
+		IncludePatterns: []string{"logs/keep.txt"},
+	})
+	assert.NoError(t, err)
+
+	// must still descend into logs, even though it's excluded, because
+	// logs/keep.txt needs a chance to be re-included.
+	assert.False(t, m.SkipDir("logs"))
+}
+
+func TestSkipDirWithWildcardReinclusionBeneath(t *testing.T) {
+	m, err := New(&FilterOpt{
+		ExcludePatterns: []string{"build/**"},
+		IncludePatterns: []string{"**/important.txt"},
+	})
+	assert.NoError(t, err)
+
+	// must still descend into build, even though it's excluded, because
+	// a leading-wildcard re-inclusion pattern could reach anywhere beneath it.
+	assert.False(t, m.SkipDir("build"))
+}
+
+func TestNilFilterOptExcludesNothing(t *testing.T) {
+	m, err := New(nil)
+	assert.NoError(t, err)
+	assert.False(t, m.ExcludesPath("anything/at/all.txt", false))
+	assert.False(t, m.SkipDir("anything"))
+}
+
+func TestDoubleStarMatchesAcrossSegments(t *testing.T) {
+	m, err := New(&FilterOpt{ExcludePatterns: []string{"**/*.log"}})
+	assert.NoError(t, err)
+
+	assert.True(t, m.ExcludesPath("a/b/c.log", false))
+	assert.True(t, m.ExcludesPath("c.log", false))
+	assert.False(t, m.ExcludesPath("c.logx", false))
+}
@@ -0,0 +1,107 @@
+// Package manifest persists per-set file fingerprints (size, mtime and a
+// SHA-256 digest) alongside each backup set, so later runs can tell
+// whether a source file changed without re-reading files that didn't, and
+// so `dhb verify` can detect bitrot by re-hashing a set against what was
+// recorded when it was created.
+package manifest
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/timabell/disk-hog-backup/dhbfs"
+)
+
+// Entry is the recorded fingerprint of a single file within a backup set.
+type Entry struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Sha256  string    `json:"sha256"`
+}
+
+// Manifest maps a file's path, relative to its set folder, to its Entry.
+type Manifest map[string]Entry
+
+// Matches reports whether info's size and mtime still agree with e, the
+// cheap check performed before falling back to a SHA-256 comparison.
+func (e Entry) Matches(info dhbfs.FileInfo) bool {
+	return e.Size == info.Size() && e.ModTime.Equal(info.ModTime())
+}
+
+// Path returns where setName's manifest lives: alongside its set folder,
+// rather than inside it, so it's never walked as a backed-up file.
+func Path(dest string, setName string) string {
+	return filepath.Join(dest, setName+".manifest.json")
+}
+
+// Load reads the manifest at path. A missing manifest (the first-ever
+// backup, or a set that predates this feature) isn't an error; it yields
+// an empty Manifest.
+func Load(fs dhbfs.FS, path string) (Manifest, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return Manifest{}, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	m := Manifest{}
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Save writes m to path as indented JSON.
+func Save(fs dhbfs.FS, path string, m Manifest) error {
+	f, err := fs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	return enc.Encode(m)
+}
+
+// Hash returns the hex-encoded SHA-256 digest of path's contents through
+// fs, aborting promptly with ctx.Err() if ctx is cancelled mid-read.
+func Hash(ctx context.Context, fs dhbfs.FS, path string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, &contextReader{ctx: ctx, r: f}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextReader wraps an io.Reader so a long hash computation notices
+// context cancellation between reads instead of running to completion.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (c *contextReader) Read(p []byte) (int, error) {
+	if err := c.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return c.r.Read(p)
+}
@@ -0,0 +1,90 @@
+package manifest
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/timabell/disk-hog-backup/dhbfs"
+)
+
+func TestSaveAndLoadRoundTrips(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	path := "/2020-01-01-00-00-00.manifest.json"
+	m := Manifest{
+		"file.txt": {Size: 5, ModTime: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), Sha256: "abc"},
+	}
+
+	assert.NoError(t, Save(fs, path, m))
+
+	loaded, err := Load(fs, path)
+	assert.NoError(t, err)
+	assert.Equal(t, m, loaded)
+}
+
+func TestLoadMissingManifestReturnsEmpty(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+
+	m, err := Load(fs, "/does-not-exist.manifest.json")
+	assert.NoError(t, err)
+	assert.Empty(t, m)
+}
+
+func TestEntryMatches(t *testing.T) {
+	modTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := Entry{Size: 5, ModTime: modTime}
+
+	assert.True(t, entry.Matches(fakeInfo{size: 5, modTime: modTime}))
+	assert.False(t, entry.Matches(fakeInfo{size: 6, modTime: modTime}))
+	assert.False(t, entry.Matches(fakeInfo{size: 5, modTime: modTime.Add(time.Second)}))
+}
+
+func TestHashDetectsContentChange(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeFile(t, fs, "/source/a.txt", "hello")
+	writeFile(t, fs, "/source/b.txt", "hello")
+	writeFile(t, fs, "/source/c.txt", "goodbye")
+
+	sumA, err := Hash(context.Background(), fs, "/source/a.txt")
+	assert.NoError(t, err)
+	sumB, err := Hash(context.Background(), fs, "/source/b.txt")
+	assert.NoError(t, err)
+	sumC, err := Hash(context.Background(), fs, "/source/c.txt")
+	assert.NoError(t, err)
+
+	assert.Equal(t, sumA, sumB, "identical content should hash the same")
+	assert.NotEqual(t, sumA, sumC, "different content should hash differently")
+}
+
+func TestHashHonoursCancelledContext(t *testing.T) {
+	fs := dhbfs.NewMemFS()
+	assert.NoError(t, fs.Mkdir("/source", os.ModePerm))
+	writeFile(t, fs, "/source/a.txt", "hello")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := Hash(ctx, fs, "/source/a.txt")
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func writeFile(t *testing.T, fs *dhbfs.MemFS, path string, contents string) {
+	f, err := fs.Create(path)
+	assert.NoError(t, err)
+	_, err = f.Write([]byte(contents))
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+}
+
+type fakeInfo struct {
+	size    int64
+	modTime time.Time
+}
+
+func (f fakeInfo) Name() string       { return "fake" }
+func (f fakeInfo) Size() int64        { return f.size }
+func (f fakeInfo) IsDir() bool        { return false }
+func (f fakeInfo) ModTime() time.Time { return f.modTime }
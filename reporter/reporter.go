@@ -0,0 +1,36 @@
+// Package reporter lets backup and dhcopy surface progress back to
+// whoever started the run, without each of them needing to know whether
+// that's a terminal, a log file or a test.
+package reporter
+
+import "context"
+
+// Reporter receives progress events as a backup walks the source tree.
+type Reporter interface {
+	FileStarted(path string, size int64)
+	FileFinished(path string, bytesCopied int64, linked bool)
+	Error(path string, err error)
+}
+
+type contextKey struct{}
+
+// WithReporter attaches r to ctx so it can be recovered with FromContext by
+// any code further down the call chain.
+func WithReporter(ctx context.Context, r Reporter) context.Context {
+	return context.WithValue(ctx, contextKey{}, r)
+}
+
+// FromContext returns the Reporter attached to ctx, or a no-op Reporter if
+// none was attached.
+func FromContext(ctx context.Context) Reporter {
+	if r, ok := ctx.Value(contextKey{}).(Reporter); ok {
+		return r
+	}
+	return noop{}
+}
+
+type noop struct{}
+
+func (noop) FileStarted(string, int64)        {}
+func (noop) FileFinished(string, int64, bool) {}
+func (noop) Error(string, error)              {}
@@ -0,0 +1,49 @@
+package reporter
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingReporter struct {
+	started  []string
+	finished []string
+	errored  []string
+}
+
+func (r *recordingReporter) FileStarted(path string, size int64) {
+	r.started = append(r.started, path)
+}
+
+func (r *recordingReporter) FileFinished(path string, bytesCopied int64, linked bool) {
+	r.finished = append(r.finished, path)
+}
+
+func (r *recordingReporter) Error(path string, err error) {
+	r.errored = append(r.errored, path)
+}
+
+func TestFromContextReturnsAttachedReporter(t *testing.T) {
+	rec := &recordingReporter{}
+	ctx := WithReporter(context.Background(), rec)
+
+	got := FromContext(ctx)
+	got.FileStarted("foo.txt", 10)
+	got.FileFinished("foo.txt", 10, false)
+	got.Error("bar.txt", errors.New("boom"))
+
+	assert.Equal(t, []string{"foo.txt"}, rec.started)
+	assert.Equal(t, []string{"foo.txt"}, rec.finished)
+	assert.Equal(t, []string{"bar.txt"}, rec.errored)
+}
+
+func TestFromContextReturnsNoopWhenUnset(t *testing.T) {
+	got := FromContext(context.Background())
+	// should not panic when nothing was attached
+	got.FileStarted("foo.txt", 10)
+	got.FileFinished("foo.txt", 10, false)
+	got.Error("bar.txt", errors.New("boom"))
+}
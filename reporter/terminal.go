@@ -0,0 +1,91 @@
+package reporter
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// TerminalReporter is the default Reporter used by the CLI: it prints a
+// running files/bytes count to stdout, plus an ETA once a total has been
+// supplied via SetTotal.
+type TerminalReporter struct {
+	mu sync.Mutex
+
+	start time.Time
+
+	totalFiles int
+	totalBytes int64
+
+	filesDone int
+	bytesDone int64
+}
+
+// NewTerminalReporter returns a TerminalReporter ready to report progress.
+func NewTerminalReporter() *TerminalReporter {
+	return &TerminalReporter{start: time.Now()}
+}
+
+// SetTotal records the expected number of files/bytes so FileFinished can
+// report an ETA. Callers that don't know the total up front can simply
+// never call it; progress is still reported, just without an ETA.
+func (t *TerminalReporter) SetTotal(totalFiles int, totalBytes int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.totalFiles = totalFiles
+	t.totalBytes = totalBytes
+}
+
+func (t *TerminalReporter) FileStarted(path string, size int64) {}
+
+func (t *TerminalReporter) FileFinished(path string, bytesCopied int64, linked bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.filesDone++
+	t.bytesDone += bytesCopied
+
+	fmt.Printf("\r%s  %d/%d files, %s%s", t.eta(), t.filesDone, t.totalFiles, humanBytes(t.bytesDone), progressSuffix(linked))
+}
+
+func (t *TerminalReporter) Error(path string, err error) {
+	fmt.Printf("\nerror backing up %s: %v\n", path, err)
+}
+
+func progressSuffix(linked bool) string {
+	if linked {
+		return " (hard-linked)"
+	}
+	return ""
+}
+
+// eta extrapolates remaining time from the average throughput so far. It
+// returns an empty string when there's nothing to extrapolate from yet.
+func (t *TerminalReporter) eta() string {
+	if t.totalBytes <= 0 || t.bytesDone <= 0 {
+		return ""
+	}
+	elapsed := time.Since(t.start)
+	remaining := t.totalBytes - t.bytesDone
+	if remaining <= 0 {
+		return "eta 0s"
+	}
+	rate := float64(t.bytesDone) / elapsed.Seconds()
+	if rate <= 0 {
+		return ""
+	}
+	eta := time.Duration(float64(remaining)/rate) * time.Second
+	return fmt.Sprintf("eta %s", eta.Round(time.Second))
+}
+
+func humanBytes(b int64) string {
+	const unit = 1024
+	if b < unit {
+		return fmt.Sprintf("%dB", b)
+	}
+	div, exp := int64(unit), 0
+	for n := b / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%ciB", float64(b)/float64(div), "KMGTPE"[exp])
+}